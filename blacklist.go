@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// blacklistStore holds the blacklisted URL substrings behind a mutex, in the
+// same spirit as Runner and proxyPool. Sessions add to it concurrently from
+// recursiveBrowse, so it replaces round-tripping through
+// viper.Set/GetStringSlice("blacklist"), which is not safe for concurrent
+// writers and silently drops updates under contention.
+type blacklistStore struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+// newBlacklistStore seeds a blacklistStore from the configured blacklist.
+func newBlacklistStore() *blacklistStore {
+	return &blacklistStore{entries: append([]string{}, viper.GetStringSlice("blacklist")...)}
+}
+
+// list returns a copy of the current blacklist entries.
+func (b *blacklistStore) list() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string{}, b.entries...)
+}
+
+// add appends entry to the blacklist, unless it is already present.
+func (b *blacklistStore) add(entry string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, existing := range b.entries {
+		if existing == entry {
+			return
+		}
+	}
+	b.entries = append(b.entries, entry)
+}
+
+// remove deletes entry from the blacklist, if present.
+func (b *blacklistStore) remove(entry string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	filtered := b.entries[:0]
+	for _, existing := range b.entries {
+		if existing != entry {
+			filtered = append(filtered, existing)
+		}
+	}
+	b.entries = filtered
+}
+
+// clear removes every blacklist entry.
+func (b *blacklistStore) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+}
+
+// size returns the number of entries currently blacklisted.
+func (b *blacklistStore) size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+// contains reports whether link contains any blacklisted substring.
+func (b *blacklistStore) contains(link string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, entry := range b.entries {
+		if strings.Contains(link, entry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// recursiveBrowse performs a recursive browsing operation starting from the given URL.
+// It continues until the specified depth is reached.
+// If an error occurs or no valid links are found, it adds the URL to the blacklist.
+// Candidate links are only followed if they pass every scope in scopes.
+//
+// Parameters:
+//   - url: The URL to start browsing from
+//   - depth: The current depth of recursion
+//   - scopes: The ordered chain of scopes candidate links must pass
+//   - rng: the random source markov.choose samples with, or nil to use the
+//     global one; non-nil lets crawl-once --seed reproduce the same walk
+func (s *session) recursiveBrowse(url string, depth int, scopes []Scope, rng *rand.Rand) {
+	log.WithFields(log.Fields{
+		"session": s.id,
+		"url":     url,
+		"depth":   depth,
+	}).Info("Recursively browsing")
+
+	if depth == 0 {
+		if _, err := s.doRequest(url); err == nil {
+			markov.recordTransition(s.lastURL, url)
+			s.lastURL = url
+		}
+		return
+	}
+
+	content, err := s.doRequest(url)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"url":   url,
+			"error": err,
+		}).Warn("Stopping and blacklisting: page error")
+		blacklist.add(url)
+		return
+	}
+	markov.recordTransition(s.lastURL, url)
+	s.lastURL = url
+
+	validLinks := s.getLinks(content, url, scopes, depth-1)
+	log.WithField("linkCount", len(validLinks)).Debug("Valid links found")
+
+	if len(validLinks) == 0 {
+		log.WithField("url", url).Warn("Stopping and blacklisting: no links")
+		blacklist.add(url)
+		return
+	}
+
+	sleepTime := rand.Intn(viper.GetInt("max_wait")-viper.GetInt("min_wait")+1) + viper.GetInt("min_wait")
+	log.WithField("sleepTime", sleepTime).Debug("Pausing")
+	time.Sleep(time.Duration(sleepTime) * time.Second)
+
+	s.recursiveBrowse(markov.choose(url, validLinks, rng), depth-1, scopes, rng)
+}
+
+// doRequest performs an HTTP GET request to the specified URL using the
+// session's own cookie jar and User-Agent, sending the previous URL visited
+// by this session as the Referer header. It logs the request details,
+// updates the session's Runner counters, and handles per-host politeness:
+// disallowed robots.txt paths are skipped, and the per-host rate limiter and
+// backoff are consulted before sending and updated from the response.
+//
+// Parameters:
+//   - url: The URL to send the request to
+//
+// Returns:
+//   - []byte: Content of the response body
+//   - error: Any error that occurred during the request
+func (s *session) doRequest(url string) ([]byte, error) {
+	if disallowedByRobots(url) {
+		log.WithField("url", url).Debug("Skipping: disallowed by robots.txt")
+		return nil, fmt.Errorf("disallowed by robots.txt: %s", url)
+	}
+
+	limiters.wait(url)
+
+	log.WithFields(log.Fields{"session": s.id, "url": url}).Debug("Requesting page...")
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+	if s.referer != "" {
+		req.Header.Set("Referer", s.referer)
+	}
+
+	host := req.URL.Host
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	requestDuration.WithLabelValues(host).Observe(time.Since(start).Seconds())
+	if err != nil {
+		if s.proxy != "" {
+			proxies.markFailed(s.proxy)
+			s.proxy = proxies.pick(s.id)
+			s.client = newSessionClient(s.proxy)
+		}
+		time.Sleep(30 * time.Second)
+		return nil, err
+	}
+	proxies.markSucceeded(s.proxy)
+	defer resp.Body.Close() // Ensure the body is always closed
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return content, err
+	}
+	s.referer = url
+
+	if warcOut != nil {
+		warcOut.writeExchange(req, resp, content)
+	}
+
+	pageSize := int64(len(content))
+	s.runner.recordRequest(host, resp.StatusCode, pageSize)
+	bytesDownloaded.Add(float64(pageSize))
+	requestsTotal.WithLabelValues(host, strconv.Itoa(resp.StatusCode)).Inc()
+
+	log.WithFields(log.Fields{
+		"pageSize":  hrBytes(pageSize),
+		"dataMeter": hrBytes(atomic.LoadInt64(&s.runner.DataMeter)),
+	}).Debug("Page size and data meter")
+
+	limiters.recordStatus(url, resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")))
+
+	if resp.StatusCode != 200 {
+		log.WithField("status", resp.StatusCode).Warn("Non-200 response status")
+		if resp.StatusCode == 429 {
+			log.Warn("We're making requests too frequently... backing off for this host...")
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"goodRequests": atomic.LoadInt64(&s.runner.GoodRequests),
+		"badRequests":  atomic.LoadInt64(&s.runner.BadRequests),
+	}).Debug("Request counters")
+
+	return content, nil
+}
+
+// getLinks extracts all valid links from the given HTTP response body.
+// It walks the document with an HTML tokenizer, resolves every link against
+// pageURL, and filters the anchors through the configured scope chain, so
+// that only links passing every scope (schemes, depth, seed, regexp,
+// robots.txt, blacklist, ...) are returned for recursion. Sub-resources
+// (images, scripts, stylesheets, ...) are not subject to scoping and are
+// optionally fetched, through this session, to round out the traffic profile.
+//
+// Parameters:
+//   - content: An []byte containing the HTTP response body
+//   - pageURL: The URL content was fetched from
+//   - scopes: The ordered chain of scopes candidate anchors must pass
+//   - depth: The recursion depth the links would be visited at
+//
+// Returns:
+//   - []string: A slice of valid anchor links found in the body
+func (s *session) getLinks(content []byte, pageURL string, scopes []Scope, depth int) []string {
+	links := extractLinks(content, pageURL)
+
+	validLinks := make([]string, 0)
+	for _, link := range links {
+		if link.Kind == "a" && checkScopes(scopes, link.URL, depth) {
+			validLinks = append(validLinks, link.URL)
+		}
+	}
+
+	s.fetchAssets(links)
+
+	return validLinks
+}
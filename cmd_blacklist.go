@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// blacklistCmd manipulates the shared blacklistStore and persists it to the
+// config file.
+var blacklistCmd = &cobra.Command{
+	Use:   "blacklist",
+	Short: "Manipulate the blacklist stored in the config file",
+}
+
+var blacklistAddCmd = &cobra.Command{
+	Use:   "add <substring>",
+	Short: "Add a substring to the blacklist",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		blacklist.add(args[0])
+		writeBlacklistConfig()
+	},
+}
+
+var blacklistRemoveCmd = &cobra.Command{
+	Use:   "remove <substring>",
+	Short: "Remove a substring from the blacklist",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		blacklist.remove(args[0])
+		writeBlacklistConfig()
+	},
+}
+
+var blacklistListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the current blacklist",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, entry := range blacklist.list() {
+			fmt.Println(entry)
+		}
+	},
+}
+
+var blacklistClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every entry from the blacklist",
+	Run: func(cmd *cobra.Command, args []string) {
+		blacklist.clear()
+		writeBlacklistConfig()
+	},
+}
+
+func init() {
+	blacklistCmd.AddCommand(blacklistAddCmd, blacklistRemoveCmd, blacklistListCmd, blacklistClearCmd)
+	rootCmd.AddCommand(blacklistCmd)
+}
+
+// writeBlacklistConfig persists the in-memory blacklist to the blacklist key
+// of the config file on disk, leaving every other key untouched. This reads
+// and rewrites only that one key, rather than going through
+// viper.WriteConfig, which serializes viper.AllSettings and would bake every
+// bound flag's default (max-depth, warc, ...) permanently into the file.
+func writeBlacklistConfig() {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		cobra.CheckErr(fmt.Errorf("no config file in use, cannot persist blacklist"))
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		cobra.CheckErr(fmt.Errorf("could not read config file: %w", err))
+	}
+
+	config := make(map[string]interface{})
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		cobra.CheckErr(fmt.Errorf("could not parse config file: %w", err))
+	}
+	config["blacklist"] = blacklist.list()
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		cobra.CheckErr(fmt.Errorf("could not serialize config file: %w", err))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		cobra.CheckErr(fmt.Errorf("could not write config file: %w", err))
+	}
+}
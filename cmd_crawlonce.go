@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// crawlOnceCmd performs a single, non-looping recursive browse of one URL
+// and prints a JSON summary, which is handy for CI or for testing a config
+// against a real site without starting the daemon.
+var crawlOnceCmd = &cobra.Command{
+	Use:   "crawl-once <url>",
+	Short: "Perform a single recursive walk from url and print a JSON summary",
+	Args:  cobra.ExactArgs(1),
+	Run:   crawlOnce,
+}
+
+func init() {
+	crawlOnceCmd.Flags().Int("depth", 3, "recursion depth for this walk")
+	crawlOnceCmd.Flags().Int64("seed", 0, "seed the link-selection RNG for a reproducible walk (0 = non-deterministic)")
+	rootCmd.AddCommand(crawlOnceCmd)
+}
+
+// crawlOnceSummary is the JSON document crawl-once prints on exit.
+type crawlOnceSummary struct {
+	RootURL      string        `json:"root_url"`
+	Depth        int           `json:"depth"`
+	Duration     time.Duration `json:"duration_ns"`
+	GoodRequests int64         `json:"good_requests"`
+	BadRequests  int64         `json:"bad_requests"`
+	DataMeter    int64         `json:"data_meter"`
+}
+
+// crawlOnce runs a single recursive browse starting at args[0] down to
+// --depth and prints a JSON summary of the walk. Passing --seed fixes the
+// random source of the Markov link selection, so walks over the same pages
+// from the same model make the same choices. Each walk also records its
+// transitions into the persisted model file, so a later run with the same
+// seed starts from different counts and can pick different links.
+func crawlOnce(cmd *cobra.Command, args []string) {
+	rootURL := args[0]
+	depth, _ := cmd.Flags().GetInt("depth")
+	viper.Set("max_depth", depth)
+
+	var rng *rand.Rand
+	if seed, _ := cmd.Flags().GetInt64("seed"); seed != 0 {
+		rng = rand.New(rand.NewSource(seed))
+	}
+
+	warcOut = newWarcWriterFromConfig()
+	markov = newMarkovModelFromConfig()
+
+	runner := NewRunner()
+	s := newSession(0, runner)
+
+	scopes := buildScopes(rootURL, robots)
+	start := time.Now()
+	s.recursiveBrowse(rootURL, depth, scopes, rng)
+
+	summary := crawlOnceSummary{
+		RootURL:      rootURL,
+		Depth:        depth,
+		Duration:     time.Since(start),
+		GoodRequests: runner.GoodRequests,
+		BadRequests:  runner.BadRequests,
+		DataMeter:    runner.DataMeter,
+	}
+
+	output, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		cobra.CheckErr(err)
+	}
+	fmt.Println(string(output))
+}
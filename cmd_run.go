@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// runCmd starts the traffic generator and runs until interrupted: this is
+// the behavior that used to live directly on rootCmd.
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Generate web traffic indefinitely",
+	Long:  `Starts the configured number of concurrent browser sessions and runs until interrupted.`,
+	Run:   runDaemon,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
+
+// runDaemon starts the configured number of concurrent browser sessions and
+// waits on them; since each session's browseLoop runs indefinitely, this
+// never returns in normal operation.
+func runDaemon(cmd *cobra.Command, args []string) {
+	numSessions := viper.GetInt("sessions")
+	if numSessions <= 0 {
+		numSessions = 1
+	}
+
+	log.WithFields(log.Fields{
+		"minDepth": viper.GetInt("min_depth"),
+		"maxDepth": viper.GetInt("max_depth"),
+		"minWait":  viper.GetInt("min_wait"),
+		"maxWait":  viper.GetInt("max_wait"),
+		"sessions": numSessions,
+	}).Info("Starting concurrent browser sessions, use Ctrl+C to abort.")
+
+	serveMetrics()
+	warcOut = newWarcWriterFromConfig()
+	markov = newMarkovModelFromConfig()
+
+	runner := NewRunner()
+	statsPath := viper.GetString("stats_file")
+	if statsPath == "" {
+		statsPath = "webtraffic-stats.json"
+	}
+	runner.startStatsWriter(statsPath, 10*time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numSessions; i++ {
+		s := newSession(i, runner)
+		activeSessions.Inc()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.browseLoop()
+		}()
+	}
+	wg.Wait()
+}
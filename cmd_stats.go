@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// statsCmd reads the stats file periodically written by `run` and prints a
+// summary, without needing to talk to the running daemon directly.
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Print request counts, data meter, top hosts, and error rates from the stats file",
+	Run:   printStats,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+}
+
+// printStats reads stats_file and prints a human-readable summary.
+func printStats(cmd *cobra.Command, args []string) {
+	path := viper.GetString("stats_file")
+	if path == "" {
+		path = "webtraffic-stats.json"
+	}
+
+	stats, err := readStats(path)
+	cobra.CheckErr(err)
+
+	fmt.Printf("Updated:       %s\n", stats.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Good requests: %d\n", stats.GoodRequests)
+	fmt.Printf("Bad requests:  %d\n", stats.BadRequests)
+	total := stats.GoodRequests + stats.BadRequests
+	if total > 0 {
+		fmt.Printf("Error rate:    %.2f%%\n", 100*float64(stats.BadRequests)/float64(total))
+	}
+	fmt.Printf("Data meter:    %s\n", hrBytes(stats.DataMeter))
+
+	type hostTotal struct {
+		host  string
+		count int64
+	}
+	hosts := make([]hostTotal, 0, len(stats.Hosts))
+	for host, hc := range stats.Hosts {
+		hosts = append(hosts, hostTotal{host, hc.Good + hc.Bad})
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].count > hosts[j].count })
+
+	fmt.Println("Top hosts:")
+	for i, h := range hosts {
+		if i >= 10 {
+			break
+		}
+		fmt.Printf("  %-40s %d requests\n", h.host, h.count)
+	}
+}
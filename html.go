@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"net/url"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/net/html"
+)
+
+// extractedLink is a URL found while walking a page's HTML, tagged with the
+// element it came from so callers can tell navigational links (anchors)
+// apart from sub-resources (images, scripts, stylesheets, ...).
+type extractedLink struct {
+	URL  string
+	Kind string
+}
+
+// linkAttr maps the tags extractLinks looks at to the attribute that carries
+// their URL.
+var linkAttr = map[string]string{
+	"a":      "href",
+	"img":    "src",
+	"script": "src",
+	"link":   "href",
+	"iframe": "src",
+	"source": "src",
+}
+
+// extractLinks walks content with an html.Tokenizer and returns every link
+// found on a, img, script, link, iframe, and source tags, resolved against
+// pageURL. This replaces the old href="..." regular expression, which only
+// matched straight-quoted http(s) anchors and missed relative URLs and every
+// other kind of sub-resource a real browser would fetch.
+//
+// Parameters:
+//   - content: the HTML document to walk
+//   - pageURL: the URL content was fetched from, used to resolve relative links
+//
+// Returns:
+//   - []extractedLink: every link found, resolved to an absolute URL
+func extractLinks(content []byte, pageURL string) []extractedLink {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"url":   pageURL,
+			"error": err,
+		}).Warn("Could not parse page URL, skipping link extraction")
+		return nil
+	}
+
+	links := make([]extractedLink, 0)
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return links
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		token := tokenizer.Token()
+		attrName, ok := linkAttr[token.Data]
+		if !ok {
+			continue
+		}
+
+		for _, attr := range token.Attr {
+			if attr.Key != attrName || attr.Val == "" {
+				continue
+			}
+			ref, err := url.Parse(attr.Val)
+			if err != nil {
+				continue
+			}
+			links = append(links, extractedLink{
+				URL:  base.ResolveReference(ref).String(),
+				Kind: token.Data,
+			})
+		}
+	}
+}
+
+// fetchAssets optionally fires GET requests, through this session, for the
+// sub-resources found on a page (images, scripts, stylesheets, ...) so that
+// the traffic profile of a crawl resembles a real browser loading a page
+// rather than just following anchors. Only links whose tag is listed in
+// asset_types are considered, and asset_fetch_fraction (0.0-1.0, default 1.0)
+// selects a random subset of those to actually fetch, since a real browser
+// page load rarely fires every possible sub-resource request (cached assets,
+// images below the fold with lazy loading, and so on). Up to
+// asset_concurrency requests are in flight at once.
+func (s *session) fetchAssets(links []extractedLink) {
+	if !viper.GetBool("fetch_assets") {
+		return
+	}
+
+	assetTypes := viper.GetStringSlice("asset_types")
+	if len(assetTypes) == 0 {
+		assetTypes = []string{"img", "script", "link"}
+	}
+	wanted := make(map[string]bool, len(assetTypes))
+	for _, t := range assetTypes {
+		wanted[t] = true
+	}
+
+	fraction := 1.0
+	if viper.IsSet("asset_fetch_fraction") {
+		fraction = viper.GetFloat64("asset_fetch_fraction")
+	}
+
+	concurrency := viper.GetInt("asset_concurrency")
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, link := range links {
+		if !wanted[link.Kind] {
+			continue
+		}
+		if fraction < 1.0 && rand.Float64() >= fraction {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(assetURL string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := s.doRequest(assetURL); err != nil {
+				log.WithFields(log.Fields{
+					"url":   assetURL,
+					"error": err,
+				}).Debug("Asset fetch failed")
+			}
+		}(link.URL)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import "testing"
+
+func TestExtractLinksResolvesRelativeURLsAndTagsKind(t *testing.T) {
+	page := `
+		<html>
+			<head><link rel="stylesheet" href="/style.css"></head>
+			<body>
+				<a href="/about">About</a>
+				<a href="https://other.example.com/page">Other</a>
+				<img src="photo.jpg">
+				<script src="/app.js"></script>
+				<a href="">Empty</a>
+			</body>
+		</html>`
+
+	links := extractLinks([]byte(page), "https://example.com/dir/index.html")
+
+	want := map[string]string{
+		"https://example.com/style.css":     "link",
+		"https://example.com/about":         "a",
+		"https://other.example.com/page":    "a",
+		"https://example.com/dir/photo.jpg": "img",
+		"https://example.com/app.js":        "script",
+	}
+
+	if len(links) != len(want) {
+		t.Fatalf("extractLinks returned %d links, want %d: %+v", len(links), len(want), links)
+	}
+	for _, link := range links {
+		kind, ok := want[link.URL]
+		if !ok {
+			t.Errorf("unexpected link %+v", link)
+			continue
+		}
+		if kind != link.Kind {
+			t.Errorf("link %q has kind %q, want %q", link.URL, link.Kind, kind)
+		}
+	}
+}
+
+func TestExtractLinksOnInvalidPageURL(t *testing.T) {
+	links := extractLinks([]byte(`<a href="/about">About</a>`), "://not-a-url")
+	if links != nil {
+		t.Errorf("expected no links when the page URL cannot be parsed, got %+v", links)
+	}
+}
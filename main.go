@@ -19,13 +19,7 @@ package main
 
 import (
 	"fmt"
-	"io"
-	"math/rand"
-	"net/http"
 	"os"
-	"regexp"
-	"strings"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -33,27 +27,35 @@ import (
 )
 
 var (
-	cfgFile      string
-	dataMeter    int64
-	goodRequests int
-	badRequests  int
-	client       = &http.Client{
-		Timeout: 5 * time.Second,
-	}
-	linkRegex = regexp.MustCompile(`(?:href=\")(https?:\/\/[^\"]+)(?:\")`)
+	cfgFile   string
+	robots    = newRobotsCache()
+	limiters  = newRateLimiters(robots)
+	warcOut   *warcWriter
+	markov    *markovModel
+	proxies   *proxyPool
+	blacklist *blacklistStore
 )
 
-// rootCmd represents the base command when called without any subcommands
+// rootCmd represents the base command when called without any subcommands.
+// It carries no behavior of its own: webtraffic run, crawl-once, stats,
+// blacklist, and train-from are the commands that actually do something.
+//
+// The first four live in cmd_run.go, cmd_crawlonce.go, cmd_stats.go, and
+// cmd_blacklist.go (train-from lives in markov.go, beside the model it
+// trains), all still in package main rather than a separate cmd
+// package: there is no go.mod here, so there is no module path for a second
+// importable package to live under, and this is a single-binary CLI with no
+// other consumer of that package. The cmd_*.go naming groups them on disk
+// the way a cmd package would without the import-path overhead.
 var rootCmd = &cobra.Command{
 	Use:   "webtraffic",
 	Short: "A web traffic generator",
 	Long:  `A CLI tool to generate web traffic for demo purposes.`,
-	Run:   run,
 }
 
 // init initializes the cobra command and sets up the flags and configuration
 func init() {
-	cobra.OnInitialize(initConfig, initLogging)
+	cobra.OnInitialize(initConfig, initLogging, initProxies, initBlacklist)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file "+
 		"(default is $PWD/.webtraffic.yaml followed by $HOME/.webtraffic.yaml)")
@@ -62,12 +64,14 @@ func init() {
 	rootCmd.PersistentFlags().Int("min-depth", 3, "minimum depth for recursive browsing")
 	rootCmd.PersistentFlags().Int("max-wait", 10, "maximum wait time between requests")
 	rootCmd.PersistentFlags().Int("min-wait", 5, "minimum wait time between requests")
+	rootCmd.PersistentFlags().String("warc", "", "write every fetched page to this WARC file")
 
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	viper.BindPFlag("max_depth", rootCmd.PersistentFlags().Lookup("max-depth"))
 	viper.BindPFlag("min_depth", rootCmd.PersistentFlags().Lookup("min-depth"))
 	viper.BindPFlag("max_wait", rootCmd.PersistentFlags().Lookup("max-wait"))
 	viper.BindPFlag("min_wait", rootCmd.PersistentFlags().Lookup("min-wait"))
+	viper.BindPFlag("warc_file", rootCmd.PersistentFlags().Lookup("warc"))
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -105,12 +109,18 @@ func initConfig() {
 
 // initLogging configures the logrus logger based on the debug flag in the configuration.
 // If debug is true, it sets the log level to Debug, otherwise it sets it to Info.
+// The log_format config key selects between human-readable text (the
+// default) and JSON, for shipping logs to Loki/ELK.
 func initLogging() {
-	log.SetFormatter(&log.TextFormatter{
-		QuoteEmptyFields: true,
-		FullTimestamp:    true,
-		TimestampFormat:  "2006-01-02 15:04:05",
-	})
+	if viper.GetString("log_format") == "json" {
+		log.SetFormatter(&log.JSONFormatter{})
+	} else {
+		log.SetFormatter(&log.TextFormatter{
+			QuoteEmptyFields: true,
+			FullTimestamp:    true,
+			TimestampFormat:  "2006-01-02 15:04:05",
+		})
+	}
 	if viper.GetBool("verbose") {
 		log.SetLevel(log.DebugLevel)
 		log.Debug("Verbose logging enabled.")
@@ -119,168 +129,21 @@ func initLogging() {
 	}
 }
 
-// run is the main function that is executed when the command is run.
-// It starts the web traffic generation process and continues indefinitely.
-func run(cmd *cobra.Command, args []string) {
-	log.Info("This webtraffic command will now run indefinitely, use Ctrl+C to abort.")
-	log.WithFields(log.Fields{
-		"minDepth": viper.GetInt("min_depth"),
-		"maxDepth": viper.GetInt("max_depth"),
-		"minWait":  viper.GetInt("min_wait"),
-		"maxWait":  viper.GetInt("max_wait"),
-	}).Debug("Configuration")
-
-	for {
-		randomURL := viper.GetStringSlice("root_urls")[rand.Intn(len(viper.GetStringSlice("root_urls")))]
-		depth := rand.Intn(viper.GetInt("max_depth")-viper.GetInt("min_depth")+1) + viper.GetInt("min_depth")
-
-		log.Infof("Randomly selected %s as the Root URL for recursive browsing.", randomURL)
-
-		recursiveBrowse(randomURL, depth)
-
-		// TODO: make this sleep time configurable
-		pauseBeforeBrowse := time.Duration(10) * time.Second
-		log.Infof("Pausing %s before choosing another Root URL.", pauseBeforeBrowse)
-		time.Sleep(pauseBeforeBrowse)
-	}
-}
-
-// recursiveBrowse performs a recursive browsing operation starting from the given URL.
-// It continues until the specified depth is reached.
-// If an error occurs or no valid links are found, it adds the URL to the blacklist.
-//
-// Parameters:
-//   - url: The URL to start browsing from
-//   - depth: The current depth of recursion
-func recursiveBrowse(url string, depth int) {
-	log.WithFields(log.Fields{
-		"url":   url,
-		"depth": depth,
-	}).Info("Recursively browsing")
-
-	if depth == 0 {
-		doRequest(url)
-		return
-	}
-
-	content, err := doRequest(url)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"url":   url,
-			"error": err,
-		}).Warn("Stopping and blacklisting: page error")
-		viper.Set("blacklist", append(viper.GetStringSlice("blacklist"), url))
-		return
-	}
-
-	validLinks := getLinks(content)
-	log.WithField("linkCount", len(validLinks)).Debug("Valid links found")
-
-	if len(validLinks) == 0 {
-		log.WithField("url", url).Warn("Stopping and blacklisting: no links")
-		viper.Set("blacklist", append(viper.GetStringSlice("blacklist"), url))
-		return
-	}
-
-	sleepTime := rand.Intn(viper.GetInt("max_wait")-viper.GetInt("min_wait")+1) + viper.GetInt("min_wait")
-	log.WithField("sleepTime", sleepTime).Debug("Pausing")
-	time.Sleep(time.Duration(sleepTime) * time.Second)
-
-	recursiveBrowse(validLinks[rand.Intn(len(validLinks))], depth-1)
-}
-
-// doRequest performs an HTTP GET request to the specified URL.
-// It logs the request details, updates request counters, and handles rate limiting.
-//
-// Parameters:
-//   - url: The URL to send the request to
-//
-// Returns:
-//   - []byte: Content of the response body
-//   - error: Any error that occurred during the request
-func doRequest(url string) ([]byte, error) {
-	log.WithField("url", url).Debug("Requesting page...")
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", viper.GetString("user_agent"))
-
-	resp, err := client.Do(req)
-	if err != nil {
-		time.Sleep(30 * time.Second)
-		return nil, err
-	}
-	defer resp.Body.Close() // Ensure the body is always closed
-
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return content, err
-	}
-
-	pageSize := int64(len(content))
-	dataMeter += pageSize
-
-	log.WithFields(log.Fields{
-		"pageSize":  hrBytes(pageSize),
-		"dataMeter": hrBytes(dataMeter),
-	}).Debug("Page size and data meter")
-
-	if resp.StatusCode != 200 {
-		badRequests++
-		log.WithField("status", resp.StatusCode).Warn("Non-200 response status")
-		if resp.StatusCode == 429 {
-			log.Warn("We're making requests too frequently... sleeping longer...")
-			viper.Set("min_wait", viper.GetInt("min_wait")+10)
-			viper.Set("max_wait", viper.GetInt("max_wait")+10)
-		}
-	} else {
-		goodRequests++
-	}
-
-	log.WithFields(log.Fields{
-		"goodRequests": goodRequests,
-		"badRequests":  badRequests,
-	}).Debug("Request counters")
-
-	return content, nil
-}
-
-// getLinks extracts all valid links from the given HTTP response body.
-// It uses a regular expression to find links and filters out blacklisted ones.
-//
-// Parameters:
-//   - content: An []byte containing the HTTP response body
-//
-// Returns:
-//   - []string: A slice of valid links found in the body
-func getLinks(content []byte) []string {
-	links := linkRegex.FindAllString(string(content), -1)
-	validLinks := make([]string, 0)
-	for _, link := range links {
-		cleanLink := link[6 : len(link)-1] // Remove href=" and "
-		if !isBlacklisted(cleanLink) {
-			validLinks = append(validLinks, cleanLink)
-		}
-	}
-	return validLinks
+// initProxies builds the proxy pool from the proxies and proxy_strategy
+// config keys once initConfig has read the config file. Built as a
+// package-var initializer instead, it would run before the file was loaded
+// and always see an empty proxy list.
+func initProxies() {
+	proxies = newProxyPool()
 }
 
-// isBlacklisted checks if a given link is in the blacklist.
-//
-// Parameters:
-//   - link: The link to check
-//
-// Returns:
-//   - bool: true if the link is blacklisted, false otherwise
-func isBlacklisted(link string) bool {
-	for _, blacklisted := range viper.GetStringSlice("blacklist") {
-		if strings.Contains(link, blacklisted) {
-			return true
-		}
-	}
-	return false
+// initBlacklist seeds the blacklist store from the blacklist config key once
+// initConfig has read the config file. Built as a package-var initializer
+// instead, it would always start empty, dropping any pre-existing entries
+// from .webtraffic.yaml and losing them the first time a blacklist subcommand
+// rewrote the file.
+func initBlacklist() {
+	blacklist = newBlacklistStore()
 }
 
 // hrBytes converts a byte size to a human-readable string.
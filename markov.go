@@ -0,0 +1,285 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// markovModel is a persisted Markov transition-count table keyed on
+// normalized URL states, used to pick the next link a session follows so
+// that generated traffic reflects realistic navigation patterns instead of
+// a uniform random walk.
+type markovModel struct {
+	mu          sync.Mutex
+	path        string
+	stateDepth  int
+	alpha       float64
+	Transitions map[string]map[string]uint64 `json:"transitions"`
+}
+
+// newMarkovModel loads the transition table from path if it exists, or
+// starts with an empty one otherwise.
+func newMarkovModel(path string, stateDepth int, alpha float64) *markovModel {
+	m := &markovModel{
+		path:        path,
+		stateDepth:  stateDepth,
+		alpha:       alpha,
+		Transitions: make(map[string]map[string]uint64),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithFields(log.Fields{"path": path, "error": err}).Warn("Could not read Markov model, starting fresh")
+		}
+		return m
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		log.WithFields(log.Fields{"path": path, "error": err}).Warn("Could not parse Markov model, starting fresh")
+		m.Transitions = make(map[string]map[string]uint64)
+	}
+	return m
+}
+
+// normalizeURLState reduces a URL to scheme+host plus its first depth path
+// segments, with purely numeric segments replaced by ":id", so that e.g.
+// /products/42/reviews and /products/43/reviews are treated as the same
+// navigation state.
+func normalizeURLState(rawURL string, depth int) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	state := u.Scheme + "://" + u.Host
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, seg := range segments {
+		if i >= depth {
+			break
+		}
+		if seg == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(seg); err == nil {
+			seg = ":id"
+		}
+		state += "/" + seg
+	}
+	return state
+}
+
+// recordTransition increments the transition count between two states and
+// persists the model to disk.
+func (m *markovModel) recordTransition(from, to string) {
+	if from == "" {
+		return
+	}
+	fromState := normalizeURLState(from, m.stateDepth)
+	toState := normalizeURLState(to, m.stateDepth)
+
+	m.mu.Lock()
+	if m.Transitions[fromState] == nil {
+		m.Transitions[fromState] = make(map[string]uint64)
+	}
+	m.Transitions[fromState][toState]++
+	m.mu.Unlock()
+
+	if err := m.save(); err != nil {
+		log.WithField("error", err).Warn("Could not persist Markov model")
+	}
+}
+
+// save writes the transition table to m.path as JSON.
+func (m *markovModel) save() error {
+	m.mu.Lock()
+	data, err := json.Marshal(m)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, 0644)
+}
+
+// choose picks one of candidates, sampling proportionally to
+// count(currentState->candidateState) + alpha (Laplace smoothing), so that
+// untried transitions still get a non-zero chance of being picked. When rng
+// is non-nil it is used instead of the global rand source, so that callers
+// needing a reproducible walk (e.g. crawl-once --seed) can get one.
+//
+// Parameters:
+//   - current: the URL the session is currently on
+//   - candidates: the candidate links to choose from
+//   - rng: the random source to sample with, or nil to use the global one
+//
+// Returns:
+//   - string: the chosen link
+func (m *markovModel) choose(current string, candidates []string, rng *rand.Rand) string {
+	currentState := normalizeURLState(current, m.stateDepth)
+
+	m.mu.Lock()
+	counts := m.Transitions[currentState]
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, candidate := range candidates {
+		candidateState := normalizeURLState(candidate, m.stateDepth)
+		weights[i] = float64(counts[candidateState]) + m.alpha
+		total += weights[i]
+	}
+	m.mu.Unlock()
+
+	var roll float64
+	if rng != nil {
+		roll = rng.Float64()
+	} else {
+		roll = rand.Float64()
+	}
+	pick := roll * total
+	for i, w := range weights {
+		pick -= w
+		if pick <= 0 {
+			return candidates[i]
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// combinedLogFormat matches an Apache/nginx "combined" access log line.
+var combinedLogFormat = regexp.MustCompile(`^(\S+) \S+ \S+ \[([^\]]+)\] "(\S+) (\S+)[^"]*" (\d{3}) (\S+) "([^"]*)" "([^"]*)"`)
+
+// logEntry is a single parsed access-log line.
+type logEntry struct {
+	ip        string
+	url       string
+	referer   string
+	timestamp time.Time
+}
+
+// sessionGap is the maximum elapsed time between two requests from the same
+// IP before trainFromAccessLog treats them as separate navigation sessions.
+const sessionGap = 30 * time.Minute
+
+// trainFromAccessLog seeds a Markov model from a Combined Log Format access
+// log: lines are grouped by client IP, sorted by timestamp, split into
+// sessions on a 30-minute gap between consecutive requests, and consecutive
+// (referer, url) pairs within each session become transitions.
+func trainFromAccessLog(m *markovModel, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	entriesByIP := make(map[string][]logEntry)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		match := combinedLogFormat.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		timestamp, err := time.Parse("02/Jan/2006:15:04:05 -0700", match[2])
+		if err != nil {
+			log.WithFields(log.Fields{"line": line, "error": err}).Debug("Skipping access log line with unparsable timestamp")
+			continue
+		}
+		ip, requestURL, referer := match[1], match[4], match[7]
+		entriesByIP[ip] = append(entriesByIP[ip], logEntry{ip: ip, url: requestURL, referer: referer, timestamp: timestamp})
+	}
+
+	trained := 0
+	for _, entries := range entriesByIP {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].timestamp.Before(entries[j].timestamp) })
+
+		var previous string
+		var previousTime time.Time
+		for _, entry := range entries {
+			if previous != "" && entry.timestamp.Sub(previousTime) > sessionGap {
+				previous = ""
+			}
+
+			from := entry.referer
+			if from == "" || from == "-" {
+				from = previous
+			}
+			if from != "" && from != "-" {
+				m.recordTransition(from, entry.url)
+				trained++
+			}
+			previous = entry.url
+			previousTime = entry.timestamp
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"path":        path,
+		"ips":         len(entriesByIP),
+		"transitions": trained,
+	}).Info("Trained Markov model from access log")
+	return nil
+}
+
+// trainFromCmd seeds the Markov model from an access log instead of running
+// the traffic generator.
+var trainFromCmd = &cobra.Command{
+	Use:   "train-from <access.log>",
+	Short: "Seed the Markov navigation model from a Combined Log Format access log",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		m := newMarkovModelFromConfig()
+		if err := trainFromAccessLog(m, args[0]); err != nil {
+			log.WithField("error", err).Fatal("Could not train Markov model")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trainFromCmd)
+}
+
+// newMarkovModelFromConfig builds a markovModel from the model_file,
+// state_depth, and smoothing_alpha config keys.
+func newMarkovModelFromConfig() *markovModel {
+	path := viper.GetString("model_file")
+	if path == "" {
+		path = "model.json"
+	}
+	stateDepth := viper.GetInt("state_depth")
+	if stateDepth <= 0 {
+		stateDepth = 2
+	}
+	alpha := viper.GetFloat64("smoothing_alpha")
+	if alpha <= 0 {
+		alpha = 1.0
+	}
+	return newMarkovModel(path, stateDepth, alpha)
+}
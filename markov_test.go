@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeURLState(t *testing.T) {
+	cases := []struct {
+		name  string
+		url   string
+		depth int
+		want  string
+	}{
+		{"root", "https://example.com/", 2, "https://example.com"},
+		{"numeric segment becomes :id", "https://example.com/products/42/reviews", 2, "https://example.com/products/:id"},
+		{"depth truncates path", "https://example.com/a/b/c", 2, "https://example.com/a/b"},
+		{"invalid url returned as-is", "://bad-url", 2, "://bad-url"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := normalizeURLState(c.url, c.depth); got != c.want {
+				t.Errorf("normalizeURLState(%q, %d) = %q, want %q", c.url, c.depth, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMarkovModelChooseDeterministicWithSeededRand(t *testing.T) {
+	m := newMarkovModel(filepath.Join(t.TempDir(), "model.json"), 2, 1.0)
+	m.recordTransition("https://example.com/a", "https://example.com/b")
+
+	candidates := []string{"https://example.com/b", "https://example.com/c"}
+	first := m.choose("https://example.com/a", candidates, rand.New(rand.NewSource(42)))
+	second := m.choose("https://example.com/a", candidates, rand.New(rand.NewSource(42)))
+
+	if first != second {
+		t.Errorf("choose with the same seed produced different results: %q vs %q", first, second)
+	}
+}
+
+func TestTrainFromAccessLogSplitsSessionsOnGap(t *testing.T) {
+	logLines := []string{
+		`10.0.0.1 - - [01/Jan/2024:00:00:00 +0000] "GET /a HTTP/1.1" 200 100 "-" "UA"`,
+		`10.0.0.1 - - [01/Jan/2024:00:05:00 +0000] "GET /b HTTP/1.1" 200 100 "-" "UA"`,
+		`10.0.0.1 - - [01/Jan/2024:23:00:00 +0000] "GET /c HTTP/1.1" 200 100 "-" "UA"`,
+	}
+	logPath := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(logPath, []byte(strings.Join(logLines, "\n")), 0644); err != nil {
+		t.Fatalf("could not write fixture access log: %v", err)
+	}
+
+	m := newMarkovModel(filepath.Join(t.TempDir(), "model.json"), 2, 1.0)
+	if err := trainFromAccessLog(m, logPath); err != nil {
+		t.Fatalf("trainFromAccessLog returned an error: %v", err)
+	}
+
+	stateA := normalizeURLState("/a", m.stateDepth)
+	stateB := normalizeURLState("/b", m.stateDepth)
+	stateC := normalizeURLState("/c", m.stateDepth)
+
+	if got := m.Transitions[stateA][stateB]; got != 1 {
+		t.Errorf("expected one /a -> /b transition within the session gap, got %d", got)
+	}
+	if got := m.Transitions[stateB][stateC]; got != 0 {
+		t.Errorf("expected no /b -> /c transition across a >30min gap, got %d", got)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Prometheus metrics describing the traffic webtraffic itself generates, so
+// a demo's observability stack has something to show for this tool's
+// activity, not just for the sites it drives.
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webtraffic_requests_total",
+		Help: "Total number of requests made, labeled by host and status code.",
+	}, []string{"host", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "webtraffic_request_duration_seconds",
+		Help: "Request duration in seconds, labeled by host.",
+	}, []string{"host"})
+
+	bytesDownloaded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "webtraffic_bytes_downloaded_total",
+		Help: "Total number of response bytes downloaded.",
+	})
+
+	activeSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "webtraffic_active_sessions",
+		Help: "Number of browser sessions currently running.",
+	})
+
+	rateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webtraffic_rate_limit_rejections_total",
+		Help: "Number of responses that triggered per-host backoff, labeled by host.",
+	}, []string{"host"})
+
+	blacklistSize = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "webtraffic_blacklist_size",
+		Help: "Number of entries currently in the blacklist.",
+	}, func() float64 {
+		return float64(blacklist.size())
+	})
+)
+
+// serveMetrics starts an HTTP server exposing Prometheus metrics on
+// metrics_addr, if configured. It runs for the lifetime of the process and
+// logs a fatal error if the listener cannot be started.
+func serveMetrics() {
+	addr := viper.GetString("metrics_addr")
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	log.WithField("addr", addr).Info("Serving Prometheus metrics")
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.WithField("error", err).Fatal("Metrics server failed")
+		}
+	}()
+}
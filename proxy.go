@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/net/proxy"
+)
+
+// proxyPool hands out proxy URLs according to the configured proxy_strategy,
+// quarantining ones that fail to connect with an exponentially increasing
+// cool-down so a single dead proxy doesn't keep getting picked.
+type proxyPool struct {
+	mu         sync.Mutex
+	proxies    []string
+	strategy   string
+	nextIndex  uint64
+	quarantine map[string]time.Time
+	failures   map[string]int
+}
+
+// newProxyPool builds a proxyPool from the proxies and proxy_strategy config
+// keys. An empty proxy list means every session connects directly.
+func newProxyPool() *proxyPool {
+	strategy := viper.GetString("proxy_strategy")
+	if strategy == "" {
+		strategy = "round_robin"
+	}
+	return &proxyPool{
+		proxies:    viper.GetStringSlice("proxies"),
+		strategy:   strategy,
+		quarantine: make(map[string]time.Time),
+		failures:   make(map[string]int),
+	}
+}
+
+// pick returns the proxy URL to use for sessionID, or "" for a direct
+// connection when no proxies are configured or all are quarantined.
+func (p *proxyPool) pick(sessionID int) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	available := make([]string, 0, len(p.proxies))
+	for _, proxyURL := range p.proxies {
+		if until, ok := p.quarantine[proxyURL]; ok && time.Now().Before(until) {
+			continue
+		}
+		available = append(available, proxyURL)
+	}
+	if len(available) == 0 {
+		return ""
+	}
+
+	switch p.strategy {
+	case "random":
+		return available[rand.Intn(len(available))]
+	case "per_session":
+		return available[sessionID%len(available)]
+	default: // round_robin
+		i := atomic.AddUint64(&p.nextIndex, 1) - 1
+		return available[int(i)%len(available)]
+	}
+}
+
+// quarantineBase is the initial cool-down applied to a proxy after its first
+// failure; each subsequent failure doubles it, capped at quarantineMax.
+const (
+	quarantineBase = 10 * time.Second
+	quarantineMax  = 10 * time.Minute
+)
+
+// markFailed quarantines proxyURL for an exponentially increasing cool-down.
+func (p *proxyPool) markFailed(proxyURL string) {
+	if proxyURL == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures[proxyURL]++
+	cooldown := quarantineBase << uint(p.failures[proxyURL]-1)
+	if cooldown > quarantineMax || cooldown <= 0 {
+		cooldown = quarantineMax
+	}
+	p.quarantine[proxyURL] = time.Now().Add(cooldown)
+
+	log.WithFields(log.Fields{
+		"proxy":    proxyURL,
+		"cooldown": cooldown,
+	}).Warn("Quarantining failing proxy")
+}
+
+// markSucceeded clears a proxy's failure count once it has worked again.
+func (p *proxyPool) markSucceeded(proxyURL string) {
+	if proxyURL == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.failures, proxyURL)
+	delete(p.quarantine, proxyURL)
+}
+
+// newTransport builds an http.Transport that dials through proxyURL (http,
+// https, or socks5), or directly when proxyURL is empty. Dial and TLS
+// timeouts, MaxIdleConnsPerHost, and IdleConnTimeout all come from config,
+// instead of relying on http.DefaultTransport's defaults.
+func newTransport(proxyURL string) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   configDuration("dial_timeout", 10*time.Second),
+		KeepAlive: configDuration("dial_keep_alive", 30*time.Second),
+	}
+
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		MaxIdleConnsPerHost: configInt("max_idle_conns_per_host", 2),
+		IdleConnTimeout:     configDuration("idle_conn_timeout", 90*time.Second),
+		TLSHandshakeTimeout: configDuration("tls_handshake_timeout", 10*time.Second),
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: viper.GetBool("insecure_skip_verify"),
+		},
+	}
+
+	if proxyURL == "" {
+		return transport, nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https":
+		transport.Proxy = http.ProxyURL(parsed)
+	case "socks5":
+		socksDialer, err := proxy.FromURL(parsed, dialer)
+		if err != nil {
+			return nil, fmt.Errorf("building SOCKS5 dialer for %q: %w", proxyURL, err)
+		}
+		transport.DialContext = func(_ context.Context, network, addr string) (net.Conn, error) {
+			return socksDialer.Dial(network, addr)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", parsed.Scheme)
+	}
+
+	return transport, nil
+}
+
+// configDuration reads an integer-seconds config key, falling back to
+// fallback when unset.
+func configDuration(key string, fallback time.Duration) time.Duration {
+	if !viper.IsSet(key) {
+		return fallback
+	}
+	return time.Duration(viper.GetInt(key)) * time.Second
+}
+
+// configInt reads an integer config key, falling back to fallback when unset
+// or non-positive.
+func configInt(key string, fallback int) int {
+	value := viper.GetInt(key)
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestProxyPool(strategy string, proxies ...string) *proxyPool {
+	return &proxyPool{
+		proxies:    proxies,
+		strategy:   strategy,
+		quarantine: make(map[string]time.Time),
+		failures:   make(map[string]int),
+	}
+}
+
+func TestProxyPoolPickRoundRobinCyclesThroughProxies(t *testing.T) {
+	p := newTestProxyPool("round_robin", "a", "b", "c")
+
+	got := []string{p.pick(0), p.pick(0), p.pick(0), p.pick(0)}
+	want := []string{"a", "b", "c", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("pick() call %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestProxyPoolPickPerSessionIsStablePerSessionID(t *testing.T) {
+	p := newTestProxyPool("per_session", "a", "b", "c")
+
+	if got := p.pick(1); got != "b" {
+		t.Errorf("pick(1) = %q, want %q", got, "b")
+	}
+	if got := p.pick(1); got != "b" {
+		t.Errorf("pick(1) on a second call = %q, want %q", got, "b")
+	}
+	if got := p.pick(4); got != "b" {
+		t.Errorf("pick(4) = %q, want %q (4%%3 == 1)", got, "b")
+	}
+}
+
+func TestProxyPoolPickRandomOnlyReturnsConfiguredProxies(t *testing.T) {
+	p := newTestProxyPool("random", "a", "b", "c")
+	allowed := map[string]bool{"a": true, "b": true, "c": true}
+
+	for i := 0; i < 20; i++ {
+		got := p.pick(0)
+		if !allowed[got] {
+			t.Fatalf("pick() returned %q, not one of the configured proxies", got)
+		}
+	}
+}
+
+func TestProxyPoolPickReturnsEmptyStringWhenNoProxiesConfigured(t *testing.T) {
+	p := newTestProxyPool("round_robin")
+	if got := p.pick(0); got != "" {
+		t.Errorf("pick() with no proxies configured = %q, want \"\"", got)
+	}
+}
+
+func TestProxyPoolPickSkipsQuarantinedProxies(t *testing.T) {
+	p := newTestProxyPool("round_robin", "a", "b")
+	p.markFailed("a")
+
+	for i := 0; i < 5; i++ {
+		if got := p.pick(0); got != "b" {
+			t.Errorf("pick() = %q, want the only non-quarantined proxy %q", got, "b")
+		}
+	}
+}
+
+func TestProxyPoolMarkFailedCooldownDoublesAndCaps(t *testing.T) {
+	p := newTestProxyPool("round_robin", "a")
+
+	p.markFailed("a")
+	first := p.quarantine["a"]
+	if until := time.Until(first); until <= 0 || until > quarantineBase+time.Second {
+		t.Fatalf("expected first cooldown to be ~quarantineBase, got %v", until)
+	}
+
+	p.markFailed("a")
+	second := p.quarantine["a"]
+	if !second.After(first) {
+		t.Errorf("expected the second failure's cooldown to extend further than the first")
+	}
+
+	for i := 0; i < 20; i++ {
+		p.markFailed("a")
+	}
+	if until := time.Until(p.quarantine["a"]); until > quarantineMax+time.Second {
+		t.Errorf("expected cooldown to be capped at quarantineMax, got %v", until)
+	}
+}
+
+func TestProxyPoolMarkSucceededClearsQuarantine(t *testing.T) {
+	p := newTestProxyPool("round_robin", "a", "b")
+	p.markFailed("a")
+
+	if got := p.pick(0); got != "b" {
+		t.Fatalf("expected quarantined proxy to be skipped, got %q", got)
+	}
+
+	p.markSucceeded("a")
+	if _, quarantined := p.quarantine["a"]; quarantined {
+		t.Error("expected markSucceeded to clear the quarantine entry")
+	}
+	if _, failed := p.failures["a"]; failed {
+		t.Error("expected markSucceeded to clear the failure count")
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+// hostState tracks the rate limiter and current backoff level for a single
+// host, so that a 429 on one host never slows down requests to any other.
+type hostState struct {
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	backoff time.Duration
+}
+
+// rateLimiters hands out a per-host hostState, seeding each one's steady
+// rate from the host's robots.txt Crawl-delay directive (falling back to
+// the configured min_wait/max_wait average when there is none).
+type rateLimiters struct {
+	mu    sync.Mutex
+	hosts map[string]*hostState
+	cache *robotsCache
+}
+
+// newRateLimiters builds an empty set of per-host rate limiters backed by
+// the given robots.txt cache.
+func newRateLimiters(cache *robotsCache) *rateLimiters {
+	return &rateLimiters{hosts: make(map[string]*hostState), cache: cache}
+}
+
+// stateFor returns the hostState for host, creating and seeding it on first
+// use.
+func (r *rateLimiters) stateFor(host, rawURL string) *hostState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if hs, ok := r.hosts[host]; ok {
+		return hs
+	}
+
+	interval := r.crawlDelay(rawURL)
+	if interval <= 0 {
+		avgWait := (viper.GetInt("min_wait") + viper.GetInt("max_wait")) / 2
+		if avgWait <= 0 {
+			avgWait = 1
+		}
+		interval = time.Duration(avgWait) * time.Second
+	}
+
+	hs := &hostState{limiter: rate.NewLimiter(rate.Every(interval), 1)}
+	r.hosts[host] = hs
+	return hs
+}
+
+// crawlDelay reads the Crawl-delay directive from the host's robots.txt, if
+// any, for the configured user agent.
+func (r *rateLimiters) crawlDelay(rawURL string) time.Duration {
+	data := r.cache.get(rawURL)
+	if data == nil {
+		return 0
+	}
+	group := data.FindGroup(viper.GetString("user_agent"))
+	if group == nil {
+		return 0
+	}
+	return group.CrawlDelay
+}
+
+// wait blocks until a request to rawURL's host is allowed to proceed,
+// honoring both the steady per-host rate and any backoff accumulated from
+// recent errors.
+func (r *rateLimiters) wait(rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	hs := r.stateFor(u.Host, rawURL)
+
+	hs.mu.Lock()
+	backoff := hs.backoff
+	hs.mu.Unlock()
+	if backoff > 0 {
+		log.WithFields(log.Fields{
+			"host":    u.Host,
+			"backoff": backoff,
+		}).Debug("Backing off before next request to host")
+		time.Sleep(backoff)
+	}
+
+	_ = hs.limiter.Wait(context.Background())
+}
+
+// maxBackoff is the ceiling applied to per-host exponential backoff, so a
+// persistently failing host cannot stall a session indefinitely.
+const maxBackoff = 5 * time.Minute
+
+// recordStatus updates a host's backoff level after a response. A 429 or
+// 5xx status doubles the backoff (seeded from Retry-After when present,
+// capped at maxBackoff, and jittered); any other status resets it.
+func (r *rateLimiters) recordStatus(rawURL string, status int, retryAfter time.Duration) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	hs := r.stateFor(u.Host, rawURL)
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if status != 429 && status < 500 {
+		hs.backoff = 0
+		return
+	}
+
+	rateLimitRejections.WithLabelValues(u.Host).Inc()
+
+	next := hs.backoff * 2
+	if next <= 0 {
+		next = time.Second
+	}
+	if retryAfter > 0 {
+		next = retryAfter
+	}
+	if next > maxBackoff {
+		next = maxBackoff
+	}
+	// Jitter by up to 20% so multiple sessions hitting the same host don't
+	// retry in lockstep.
+	next += time.Duration(rand.Int63n(int64(next)/5 + 1))
+
+	hs.backoff = next
+	log.WithFields(log.Fields{
+		"host":    u.Host,
+		"status":  status,
+		"backoff": hs.backoff,
+	}).Warn("Increasing backoff for host")
+}
+
+// parseRetryAfter parses the Retry-After response header, which per RFC 9110
+// is either a number of seconds or an HTTP-date. Only the seconds form is
+// supported; an empty or unparseable header returns zero.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// disallowedByRobots reports whether rawURL is disallowed for the
+// configured user agent by its host's robots.txt. Set ignore_robots_disallow
+// to bypass this check for demo environments that want full control over
+// the crawl.
+func disallowedByRobots(rawURL string) bool {
+	if viper.GetBool("ignore_robots_disallow") {
+		return false
+	}
+	data := robots.get(rawURL)
+	if data == nil {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	group := data.FindGroup(viper.GetString("user_agent"))
+	return !group.Test(u.Path)
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMain seeds the proxies pool before any test runs, since it is normally
+// built by initProxies via cobra.OnInitialize, which never fires under `go
+// test`. Without it, recordStatus's crawl-delay lookup panics by dereferencing
+// a nil *proxyPool the first time it tries a (fast-failing) robots.txt fetch.
+func TestMain(m *testing.M) {
+	proxies = newProxyPool()
+	os.Exit(m.Run())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{"empty header", "", 0},
+		{"non-numeric header", "Wed, 21 Oct 2015 07:28:00 GMT", 0},
+		{"negative seconds", "-5", 0},
+		{"valid seconds", "120", 120 * time.Second},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := parseRetryAfter(c.header); got != c.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", c.header, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRecordStatusGrowsBackoffAndCapsAtMaxBackoff(t *testing.T) {
+	r := newRateLimiters(newRobotsCache())
+	const rawURL = "https://example.com/page"
+
+	r.recordStatus(rawURL, 429, 0)
+	hs := r.stateFor("example.com", rawURL)
+	hs.mu.Lock()
+	first := hs.backoff
+	hs.mu.Unlock()
+	if first <= 0 || first > time.Second+time.Second/5 {
+		t.Fatalf("expected first backoff to be ~1s plus jitter, got %v", first)
+	}
+
+	for i := 0; i < 20; i++ {
+		r.recordStatus(rawURL, 503, 0)
+	}
+	hs.mu.Lock()
+	grown := hs.backoff
+	hs.mu.Unlock()
+	if grown <= first {
+		t.Errorf("expected backoff to grow past the first value %v, got %v", first, grown)
+	}
+	if grown > maxBackoff+maxBackoff/5 {
+		t.Errorf("expected backoff to be capped around maxBackoff, got %v", grown)
+	}
+}
+
+func TestRecordStatusResetsBackoffOnSuccess(t *testing.T) {
+	r := newRateLimiters(newRobotsCache())
+	const rawURL = "https://example.com/page"
+
+	r.recordStatus(rawURL, 429, 0)
+	hs := r.stateFor("example.com", rawURL)
+	hs.mu.Lock()
+	before := hs.backoff
+	hs.mu.Unlock()
+	if before <= 0 {
+		t.Fatalf("expected a non-zero backoff after a 429, got %v", before)
+	}
+
+	r.recordStatus(rawURL, 200, 0)
+	hs.mu.Lock()
+	after := hs.backoff
+	hs.mu.Unlock()
+	if after != 0 {
+		t.Errorf("expected backoff to reset to 0 after a 200, got %v", after)
+	}
+}
+
+func TestRecordStatusSeedsBackoffFromRetryAfter(t *testing.T) {
+	r := newRateLimiters(newRobotsCache())
+	const rawURL = "https://example.com/page"
+
+	r.recordStatus(rawURL, 429, 30*time.Second)
+	hs := r.stateFor("example.com", rawURL)
+	hs.mu.Lock()
+	backoff := hs.backoff
+	hs.mu.Unlock()
+	if backoff < 30*time.Second || backoff > 30*time.Second+30*time.Second/5+time.Second {
+		t.Errorf("expected backoff seeded from Retry-After (~30s plus jitter), got %v", backoff)
+	}
+}
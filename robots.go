@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/temoto/robotstxt"
+)
+
+// robotsCache fetches and memoizes robots.txt documents per host so that
+// repeated lookups for the same host during a crawl don't re-fetch the file.
+type robotsCache struct {
+	mu   sync.Mutex
+	data map[string]*robotstxt.RobotsData
+}
+
+// newRobotsCache creates an empty robots.txt cache.
+func newRobotsCache() *robotsCache {
+	return &robotsCache{data: make(map[string]*robotstxt.RobotsData)}
+}
+
+// get returns the parsed robots.txt for the host of rawURL, fetching and
+// caching it on first use. A nil result (with no error) means the host has
+// no robots.txt, or it could not be retrieved, in which case callers should
+// treat every path as allowed.
+func (c *robotsCache) get(rawURL string) *robotstxt.RobotsData {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	host := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	if data, ok := c.data[host]; ok {
+		c.mu.Unlock()
+		return data
+	}
+	c.mu.Unlock()
+
+	data := fetchRobots(host)
+
+	c.mu.Lock()
+	c.data[host] = data
+	c.mu.Unlock()
+
+	return data
+}
+
+// fetchRobots retrieves and parses the robots.txt file for the given host.
+// It returns nil if the file cannot be fetched or parsed. Like session
+// requests, it goes out through the configured proxy chain, so that
+// robots.txt lookups don't leak the real source IP and honor
+// insecure_skip_verify in self-signed demo environments.
+func fetchRobots(host string) *robotstxt.RobotsData {
+	robotsURL := host + "/robots.txt"
+
+	req, err := http.NewRequest("GET", robotsURL, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", viper.GetString("user_agent"))
+
+	proxyURL := proxies.pick(0)
+	transport, err := newTransport(proxyURL)
+	if err != nil {
+		log.WithFields(log.Fields{"proxy": proxyURL, "error": err}).Warn("Could not build proxy transport for robots.txt fetch, connecting directly")
+		transport, _ = newTransport("")
+	}
+
+	httpClient := &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"host":  host,
+			"error": err,
+		}).Debug("Could not fetch robots.txt")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	data, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"host":  host,
+			"error": err,
+		}).Debug("Could not parse robots.txt")
+		return nil
+	}
+	return data
+}
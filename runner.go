@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// hostCounts tallies good and bad responses seen from a single host.
+type hostCounts struct {
+	Good int64 `json:"good"`
+	Bad  int64 `json:"bad"`
+}
+
+// Runner holds the request/byte counters a webtraffic invocation
+// accumulates, replacing the package-level goodRequests/badRequests/
+// dataMeter globals the daemon used to mutate directly. A Runner is created
+// once per command invocation and passed down to every session, so tests and
+// concurrent commands never share mutable package state.
+type Runner struct {
+	GoodRequests int64 // atomic
+	BadRequests  int64 // atomic
+	DataMeter    int64 // atomic
+
+	mu    sync.Mutex
+	hosts map[string]*hostCounts
+}
+
+// NewRunner creates an empty Runner.
+func NewRunner() *Runner {
+	return &Runner{hosts: make(map[string]*hostCounts)}
+}
+
+// recordRequest tallies the outcome of a single request against host.
+func (r *Runner) recordRequest(host string, status int, bytes int64) {
+	atomic.AddInt64(&r.DataMeter, bytes)
+
+	r.mu.Lock()
+	hc, ok := r.hosts[host]
+	if !ok {
+		hc = &hostCounts{}
+		r.hosts[host] = hc
+	}
+	if status == 200 {
+		hc.Good++
+		atomic.AddInt64(&r.GoodRequests, 1)
+	} else {
+		hc.Bad++
+		atomic.AddInt64(&r.BadRequests, 1)
+	}
+	r.mu.Unlock()
+}
+
+// RunnerStats is the JSON snapshot of a Runner's counters, written to the
+// stats file by `run` and read back by the `stats` subcommand.
+type RunnerStats struct {
+	UpdatedAt    time.Time             `json:"updated_at"`
+	GoodRequests int64                 `json:"good_requests"`
+	BadRequests  int64                 `json:"bad_requests"`
+	DataMeter    int64                 `json:"data_meter"`
+	Hosts        map[string]hostCounts `json:"hosts"`
+}
+
+// snapshot returns a point-in-time copy of the Runner's counters.
+func (r *Runner) snapshot() RunnerStats {
+	r.mu.Lock()
+	hosts := make(map[string]hostCounts, len(r.hosts))
+	for host, hc := range r.hosts {
+		hosts[host] = *hc
+	}
+	r.mu.Unlock()
+
+	return RunnerStats{
+		UpdatedAt:    time.Now(),
+		GoodRequests: atomic.LoadInt64(&r.GoodRequests),
+		BadRequests:  atomic.LoadInt64(&r.BadRequests),
+		DataMeter:    atomic.LoadInt64(&r.DataMeter),
+		Hosts:        hosts,
+	}
+}
+
+// writeStats marshals the Runner's current snapshot to path as JSON.
+func (r *Runner) writeStats(path string) error {
+	data, err := json.MarshalIndent(r.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// startStatsWriter periodically writes the Runner's snapshot to path (and
+// does nothing when path is empty), so the stats subcommand has something to
+// read while the daemon keeps running.
+func (r *Runner) startStatsWriter(path string, interval time.Duration) {
+	if path == "" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := r.writeStats(path); err != nil {
+				log.WithFields(log.Fields{"path": path, "error": err}).Warn("Could not write stats file")
+			}
+		}
+	}()
+}
+
+// readStats reads and parses a stats file written by startStatsWriter.
+func readStats(path string) (RunnerStats, error) {
+	var stats RunnerStats
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return stats, err
+	}
+	err = json.Unmarshal(data, &stats)
+	return stats, err
+}
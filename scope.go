@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Scope decides whether a candidate link may be followed during recursive
+// browsing. A chain of scopes is built from the `scopes` configuration key
+// and a link must pass every scope in the chain to be considered valid.
+type Scope interface {
+	// Check reports whether link may be followed, given the recursion depth
+	// it would be visited at.
+	Check(link string, depth int) bool
+}
+
+// SchemeScope allows only links whose URL scheme appears in Allowed.
+type SchemeScope struct {
+	Allowed map[string]bool
+}
+
+// NewSchemeScope builds a SchemeScope from a list of allowed schemes, e.g.
+// []string{"http", "https"}.
+func NewSchemeScope(schemes []string) *SchemeScope {
+	allowed := make(map[string]bool, len(schemes))
+	for _, s := range schemes {
+		allowed[strings.ToLower(s)] = true
+	}
+	return &SchemeScope{Allowed: allowed}
+}
+
+func (s *SchemeScope) Check(link string, _ int) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return s.Allowed[strings.ToLower(u.Scheme)]
+}
+
+// DepthScope rejects links that would be visited past the configured
+// maximum recursion depth.
+type DepthScope struct {
+	MaxDepth int
+}
+
+// NewDepthScope builds a DepthScope enforcing the given maximum depth.
+func NewDepthScope(maxDepth int) *DepthScope {
+	return &DepthScope{MaxDepth: maxDepth}
+}
+
+func (s *DepthScope) Check(_ string, depth int) bool {
+	return depth >= 0 && depth <= s.MaxDepth
+}
+
+// SeedScope keeps browsing within the seed hosts, or within the same
+// registered domain (e.g. www.example.com and shop.example.com both match
+// a example.com seed).
+type SeedScope struct {
+	Hosts []string
+}
+
+// NewSeedScope builds a SeedScope from the hosts of one or more seed URLs.
+func NewSeedScope(seedURLs []string) *SeedScope {
+	hosts := make([]string, 0, len(seedURLs))
+	for _, seed := range seedURLs {
+		if u, err := url.Parse(seed); err == nil && u.Host != "" {
+			hosts = append(hosts, strings.ToLower(u.Host))
+		}
+	}
+	return &SeedScope{Hosts: hosts}
+}
+
+func (s *SeedScope) Check(link string, _ int) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	for _, seedHost := range s.Hosts {
+		if host == seedHost || sameRegisteredDomain(host, seedHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameRegisteredDomain reports whether two hosts share the same last two
+// dot-separated labels, e.g. "shop.example.com" and "www.example.com" both
+// resolve to "example.com". This is a simplification of a full public
+// suffix list lookup, sufficient for steering a demo crawl within a site.
+func sameRegisteredDomain(a, b string) bool {
+	registrable := func(host string) string {
+		parts := strings.Split(host, ".")
+		if len(parts) < 2 {
+			return host
+		}
+		return strings.Join(parts[len(parts)-2:], ".")
+	}
+	return registrable(a) == registrable(b)
+}
+
+// RegexpScope includes or excludes links based on configured regular
+// expressions. A link must match at least one include pattern (if any are
+// configured) and must not match any exclude pattern.
+type RegexpScope struct {
+	Include []*regexp.Regexp
+	Exclude []*regexp.Regexp
+}
+
+// NewRegexpScope compiles the include/exclude pattern lists. Patterns that
+// fail to compile are logged and skipped.
+func NewRegexpScope(includePatterns, excludePatterns []string) *RegexpScope {
+	compile := func(patterns []string) []*regexp.Regexp {
+		compiled := make([]*regexp.Regexp, 0, len(patterns))
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"pattern": p,
+					"error":   err,
+				}).Warn("Ignoring invalid scope regexp")
+				continue
+			}
+			compiled = append(compiled, re)
+		}
+		return compiled
+	}
+	return &RegexpScope{
+		Include: compile(includePatterns),
+		Exclude: compile(excludePatterns),
+	}
+}
+
+func (s *RegexpScope) Check(link string, _ int) bool {
+	for _, re := range s.Exclude {
+		if re.MatchString(link) {
+			return false
+		}
+	}
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, re := range s.Include {
+		if re.MatchString(link) {
+			return true
+		}
+	}
+	return false
+}
+
+// RobotsScope honors the crawl directives of the robots.txt file published
+// by each host, fetched once per host and cached for the lifetime of the
+// process.
+type RobotsScope struct {
+	UserAgent string
+	Cache     *robotsCache
+}
+
+// NewRobotsScope builds a RobotsScope backed by a shared robots.txt cache.
+func NewRobotsScope(userAgent string, cache *robotsCache) *RobotsScope {
+	return &RobotsScope{UserAgent: userAgent, Cache: cache}
+}
+
+func (s *RobotsScope) Check(link string, _ int) bool {
+	data := s.Cache.get(link)
+	if data == nil {
+		// No robots.txt, or it could not be fetched: allow by default.
+		return true
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	group := data.FindGroup(s.UserAgent)
+	return group.Test(u.Path)
+}
+
+// BlacklistScope rejects links matching any entry of a blacklistStore. It
+// replaces the ad-hoc isBlacklisted check so that the blacklist participates
+// in the same scope chain as every other filter.
+type BlacklistScope struct {
+	Store *blacklistStore
+}
+
+// NewBlacklistScope builds a BlacklistScope backed by a shared blacklistStore.
+func NewBlacklistScope(store *blacklistStore) *BlacklistScope {
+	return &BlacklistScope{Store: store}
+}
+
+func (s *BlacklistScope) Check(link string, _ int) bool {
+	return !s.Store.contains(link)
+}
+
+// defaultScopeOrder is used when the `scopes` configuration key is absent,
+// preserving the set of checks webtraffic has always applied plus the new
+// ones introduced alongside the Scope interface.
+var defaultScopeOrder = []string{"scheme", "depth", "seed", "regexp", "robots", "blacklist"}
+
+// buildScopes assembles the ordered chain of scopes configured for a crawl
+// rooted at seedURL, reading scope parameters from Viper.
+//
+// Parameters:
+//   - seedURL: the root URL the crawl started from, used by SeedScope
+//   - cache: the shared robots.txt cache used by RobotsScope
+//
+// Returns:
+//   - []Scope: the ordered chain a candidate link must pass in full
+func buildScopes(seedURL string, cache *robotsCache) []Scope {
+	order := viper.GetStringSlice("scopes")
+	if len(order) == 0 {
+		order = defaultScopeOrder
+	}
+
+	allowedSchemes := viper.GetStringSlice("allowed_schemes")
+	if len(allowedSchemes) == 0 {
+		allowedSchemes = []string{"http", "https"}
+	}
+
+	scopes := make([]Scope, 0, len(order))
+	for _, name := range order {
+		switch name {
+		case "scheme":
+			scopes = append(scopes, NewSchemeScope(allowedSchemes))
+		case "depth":
+			scopes = append(scopes, NewDepthScope(viper.GetInt("max_depth")))
+		case "seed":
+			scopes = append(scopes, NewSeedScope([]string{seedURL}))
+		case "regexp":
+			scopes = append(scopes, NewRegexpScope(viper.GetStringSlice("include_patterns"), viper.GetStringSlice("exclude_patterns")))
+		case "robots":
+			if viper.GetBool("respect_robots") || !viper.IsSet("respect_robots") {
+				scopes = append(scopes, NewRobotsScope(viper.GetString("user_agent"), cache))
+			}
+		case "blacklist":
+			scopes = append(scopes, NewBlacklistScope(blacklist))
+		default:
+			log.WithField("scope", name).Warn("Ignoring unknown scope in configuration")
+		}
+	}
+	return scopes
+}
+
+// checkScopes reports whether link passes every scope in the chain.
+func checkScopes(scopes []Scope, link string, depth int) bool {
+	for _, s := range scopes {
+		if !s.Check(link, depth) {
+			return false
+		}
+	}
+	return true
+}
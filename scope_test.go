@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import "testing"
+
+func TestSameRegisteredDomain(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"shop.example.com", "www.example.com", true},
+		{"example.com", "example.com", true},
+		{"example.com", "example.org", false},
+		{"sub.example.com", "example.net", false},
+	}
+	for _, c := range cases {
+		if got := sameRegisteredDomain(c.a, c.b); got != c.want {
+			t.Errorf("sameRegisteredDomain(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestCheckScopesRequiresEveryScopeToPass(t *testing.T) {
+	scopes := []Scope{
+		NewSchemeScope([]string{"https"}),
+		NewDepthScope(2),
+		NewBlacklistScope(newBlacklistStore()),
+	}
+
+	if !checkScopes(scopes, "https://example.com/page", 1) {
+		t.Error("expected an https link within depth and not blacklisted to pass")
+	}
+	if checkScopes(scopes, "http://example.com/page", 1) {
+		t.Error("expected an http link to fail the scheme scope")
+	}
+	if checkScopes(scopes, "https://example.com/page", 5) {
+		t.Error("expected a link past max depth to fail the depth scope")
+	}
+}
+
+func TestBlacklistScopeRejectsBlacklistedSubstrings(t *testing.T) {
+	store := newBlacklistStore()
+	store.add("/logout")
+	scope := NewBlacklistScope(store)
+
+	if scope.Check("https://example.com/logout?x=1", 0) {
+		t.Error("expected a link containing a blacklisted substring to be rejected")
+	}
+	if !scope.Check("https://example.com/home", 0) {
+		t.Error("expected a link not containing any blacklisted substring to pass")
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// session represents a single simulated browser: its own cookie jar, its own
+// User-Agent, and its own referer chain, so that concurrently running
+// sessions don't share login state or look like a single client hammering a
+// site. Multiple sessions run concurrently, coordinated only by the shared
+// per-host rate limiter.
+type session struct {
+	id        int
+	runner    *Runner
+	client    *http.Client
+	proxy     string
+	userAgent string
+	referer   string
+	lastURL   string
+	startedAt time.Time
+}
+
+// newSession builds a session with a fresh cookie jar, a User-Agent assigned
+// from the configured user_agents list, and a proxy picked from the shared
+// proxyPool according to proxy_strategy. Every request it sends tallies
+// against runner.
+func newSession(id int, runner *Runner) *session {
+	s := &session{
+		id:        id,
+		runner:    runner,
+		userAgent: userAgentFor(id),
+		startedAt: time.Now(),
+	}
+	s.proxy = proxies.pick(id)
+	s.client = newSessionClient(s.proxy)
+	return s
+}
+
+// newSessionClient builds an http.Client with its own cookie jar and a
+// Transport dialing through proxyURL (or directly, when empty), so that
+// session cookies (logins, CSRF tokens, ...) are retained across requests
+// within a session but never leak into another one.
+func newSessionClient(proxyURL string) *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		log.WithField("error", err).Warn("Could not create cookie jar, sessions will not retain cookies")
+	}
+	transport, err := newTransport(proxyURL)
+	if err != nil {
+		log.WithFields(log.Fields{"proxy": proxyURL, "error": err}).Warn("Could not build proxy transport, connecting directly")
+		transport, _ = newTransport("")
+	}
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Jar:       jar,
+		Transport: transport,
+	}
+}
+
+// userAgentFor picks a User-Agent for session id from the configured
+// user_agents list, rotating through it so concurrent sessions don't all
+// look identical. Falls back to the single user_agent key when the list is
+// empty.
+func userAgentFor(id int) string {
+	userAgents := viper.GetStringSlice("user_agents")
+	if len(userAgents) == 0 {
+		return viper.GetString("user_agent")
+	}
+	return userAgents[id%len(userAgents)]
+}
+
+// renewIfExpired replaces the session's cookie jar and client once
+// session_ttl has elapsed, simulating a returning visitor starting a fresh
+// browser session. A session_ttl of zero disables renewal.
+func (s *session) renewIfExpired() {
+	ttl := viper.GetInt("session_ttl")
+	if ttl <= 0 {
+		return
+	}
+	if time.Since(s.startedAt) < time.Duration(ttl)*time.Second {
+		return
+	}
+
+	log.WithField("session", s.id).Info("Session TTL reached, starting a fresh session")
+	s.proxy = proxies.pick(s.id)
+	s.client = newSessionClient(s.proxy)
+	s.referer = ""
+	s.startedAt = time.Now()
+}
+
+// browseLoop repeatedly picks a random root URL and recursively browses it,
+// pausing between root URLs, exactly as the single-threaded run loop used
+// to, but scoped to this session's own client, cookies, and User-Agent.
+func (s *session) browseLoop() {
+	for {
+		s.renewIfExpired()
+
+		rootURLs := viper.GetStringSlice("root_urls")
+		randomURL := rootURLs[rand.Intn(len(rootURLs))]
+		depth := rand.Intn(viper.GetInt("max_depth")-viper.GetInt("min_depth")+1) + viper.GetInt("min_depth")
+
+		log.WithField("session", s.id).Infof("Randomly selected %s as the Root URL for recursive browsing.", randomURL)
+
+		s.lastURL = ""
+		scopes := buildScopes(randomURL, robots)
+		s.recursiveBrowse(randomURL, depth, scopes, nil)
+
+		// TODO: make this sleep time configurable
+		pauseBeforeBrowse := time.Duration(10) * time.Second
+		log.WithField("session", s.id).Infof("Pausing %s before choosing another Root URL.", pauseBeforeBrowse)
+		time.Sleep(pauseBeforeBrowse)
+	}
+}
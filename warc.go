@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// warcWriter appends WARC 1.1 records to a gzip-per-record WARC file, so a
+// synthetic browsing session can be replayed or fed into archive analysis
+// tools later. Every record is its own gzip member, which keeps the file a
+// valid concatenation of independently decompressible records as the WARC
+// spec recommends.
+type warcWriter struct {
+	mu       sync.Mutex
+	basePath string
+	file     *os.File
+	written  int64
+	maxSize  int64
+	part     int
+}
+
+// newWarcWriter creates (or truncates) path and writes the leading warcinfo
+// record. maxSize of zero disables size-based rollover.
+func newWarcWriter(path string, maxSize int64) (*warcWriter, error) {
+	w := &warcWriter{basePath: path, maxSize: maxSize}
+	if err := w.openFile(); err != nil {
+		return nil, err
+	}
+	if err := w.writeRecord("warcinfo", "", warcinfoBody(), "application/warc-fields"); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// openFile opens the current part's file for writing, truncating it.
+func (w *warcWriter) openFile() error {
+	path := w.basePath
+	if w.part > 0 {
+		path = fmt.Sprintf("%s.%d", w.basePath, w.part)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.written = 0
+	return nil
+}
+
+// rolloverIfNeeded closes the current file and opens the next part once
+// maxSize has been exceeded.
+func (w *warcWriter) rolloverIfNeeded() error {
+	if w.maxSize <= 0 || w.written < w.maxSize {
+		return nil
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.part++
+	return w.openFile()
+}
+
+// warcinfoBody builds the key: value body of the warcinfo record.
+func warcinfoBody() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "software: webtraffic\r\n")
+	fmt.Fprintf(&buf, "format: WARC File Format 1.1\r\n")
+	return buf.Bytes()
+}
+
+// writeRecord gzip-compresses and appends a single WARC record to the
+// current file, rolling over to a new part first if the current one has
+// grown past warc_max_size.
+//
+// Parameters:
+//   - recordType: the WARC-Type (warcinfo, request, response)
+//   - targetURI: the WARC-Target-URI, empty for warcinfo
+//   - body: the record's block content
+//   - contentType: the record's Content-Type header
+func (w *warcWriter) writeRecord(recordType, targetURI string, body []byte, contentType string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.rolloverIfNeeded(); err != nil {
+		return err
+	}
+
+	var header bytes.Buffer
+	header.WriteString("WARC/1.1\r\n")
+	fmt.Fprintf(&header, "WARC-Type: %s\r\n", recordType)
+	if targetURI != "" {
+		fmt.Fprintf(&header, "WARC-Target-URI: %s\r\n", targetURI)
+	}
+	fmt.Fprintf(&header, "WARC-Date: %s\r\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&header, "WARC-Record-ID: <urn:uuid:%s>\r\n", uuid.NewString())
+	if recordType == "response" {
+		fmt.Fprintf(&header, "WARC-Payload-Digest: %s\r\n", sha1Digest(body))
+	}
+	fmt.Fprintf(&header, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&header, "Content-Length: %d\r\n", len(body))
+	header.WriteString("\r\n")
+
+	var record bytes.Buffer
+	record.Write(header.Bytes())
+	record.Write(body)
+	record.WriteString("\r\n\r\n")
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	if _, err := gz.Write(record.Bytes()); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	n, err := w.file.Write(gzipped.Bytes())
+	w.written += int64(n)
+	return err
+}
+
+// sha1Digest formats the SHA-1 digest of payload in the "sha1:<base32>" form
+// conventionally used for WARC-Payload-Digest.
+func sha1Digest(payload []byte) string {
+	sum := sha1.Sum(payload)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// writeExchange records a request/response pair as a pair of WARC records.
+func (w *warcWriter) writeExchange(req *http.Request, resp *http.Response, body []byte) {
+	reqDump, err := httputil.DumpRequestOut(req, false)
+	if err != nil {
+		log.WithField("error", err).Warn("Could not dump request for WARC record")
+		return
+	}
+	if err := w.writeRecord("request", req.URL.String(), reqDump, "application/http; msgtype=request"); err != nil {
+		log.WithField("error", err).Warn("Could not write WARC request record")
+	}
+
+	respDump, err := dumpResponse(resp, body)
+	if err != nil {
+		log.WithField("error", err).Warn("Could not dump response for WARC record")
+		return
+	}
+	if err := w.writeRecord("response", req.URL.String(), respDump, "application/http; msgtype=response"); err != nil {
+		log.WithField("error", err).Warn("Could not write WARC response record")
+	}
+}
+
+// dumpResponse renders resp's status line, headers, and body (already read
+// from the network and passed in separately, since resp.Body has been
+// consumed by the time doRequest is done with it) as raw HTTP bytes.
+func dumpResponse(resp *http.Response, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\r\n", resp.Proto, resp.Status)
+	if err := resp.Header.Write(&buf); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// close flushes and closes the underlying file.
+func (w *warcWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// newWarcWriterFromConfig builds a warcWriter from the warc_file and
+// warc_max_size config keys, or returns nil if warc_file is unset.
+func newWarcWriterFromConfig() *warcWriter {
+	path := viper.GetString("warc_file")
+	if path == "" {
+		return nil
+	}
+	maxSizeMB := viper.GetInt64("warc_max_size")
+	w, err := newWarcWriter(path, maxSizeMB*1024*1024)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"path":  path,
+			"error": err,
+		}).Fatal("Could not open WARC output file")
+	}
+	return w
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2024. Consulteer InCyber AG <incyber@consulteer.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the “Software”), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of
+ * the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS
+ * OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readGzipMembers splits path into its concatenated gzip members and
+// decompresses each one, mirroring how a WARC reader walks a gzip-per-record
+// file.
+func readGzipMembers(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", path, err)
+	}
+
+	var records []string
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("could not open gzip member: %v", err)
+		}
+		// Without this, gzip.Reader treats the whole file as one multistream
+		// and decodes every record in a single Read, leaving nothing for the
+		// next loop iteration. Disabling it stops at the member boundary and
+		// (since r is a bytes.Reader, an io.ByteReader) leaves r positioned
+		// right after it.
+		gz.Multistream(false)
+		body, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatalf("could not read gzip member: %v", err)
+		}
+		records = append(records, string(body))
+	}
+	return records
+}
+
+func TestNewWarcWriterWritesLeadingWarcinfoRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.warc.gz")
+	w, err := newWarcWriter(path, 0)
+	if err != nil {
+		t.Fatalf("newWarcWriter returned an error: %v", err)
+	}
+	defer w.close()
+
+	records := readGzipMembers(t, path)
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record after newWarcWriter, got %d", len(records))
+	}
+	if !strings.Contains(records[0], "WARC-Type: warcinfo") {
+		t.Errorf("expected a warcinfo record, got %q", records[0])
+	}
+}
+
+func TestWriteRecordIncludesPayloadDigestOnlyForResponses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.warc.gz")
+	w, err := newWarcWriter(path, 0)
+	if err != nil {
+		t.Fatalf("newWarcWriter returned an error: %v", err)
+	}
+	defer w.close()
+
+	body := []byte("hello world")
+	if err := w.writeRecord("request", "https://example.com/", body, "application/http; msgtype=request"); err != nil {
+		t.Fatalf("writeRecord(request) returned an error: %v", err)
+	}
+	if err := w.writeRecord("response", "https://example.com/", body, "application/http; msgtype=response"); err != nil {
+		t.Fatalf("writeRecord(response) returned an error: %v", err)
+	}
+
+	records := readGzipMembers(t, path)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records (warcinfo, request, response), got %d", len(records))
+	}
+
+	request, response := records[1], records[2]
+	if !strings.Contains(request, "WARC-Type: request") {
+		t.Errorf("expected a request record, got %q", request)
+	}
+	if strings.Contains(request, "WARC-Payload-Digest:") {
+		t.Error("expected no WARC-Payload-Digest on a request record")
+	}
+	if !strings.Contains(response, "WARC-Type: response") {
+		t.Errorf("expected a response record, got %q", response)
+	}
+	wantDigest := sha1Digest(body)
+	if !strings.Contains(response, "WARC-Payload-Digest: "+wantDigest) {
+		t.Errorf("expected response record to contain digest %q, got %q", wantDigest, response)
+	}
+	if !strings.Contains(response, string(body)) {
+		t.Error("expected response record to contain the payload body")
+	}
+}
+
+func TestWriteRecordRolloverCreatesNewPartOnceMaxSizeExceeded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.warc.gz")
+	w, err := newWarcWriter(path, 1)
+	if err != nil {
+		t.Fatalf("newWarcWriter returned an error: %v", err)
+	}
+	defer w.close()
+
+	if err := w.writeRecord("request", "https://example.com/", []byte("payload"), "application/http; msgtype=request"); err != nil {
+		t.Fatalf("writeRecord returned an error: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected a rolled-over part file %s.1 to exist: %v", path, err)
+	}
+}